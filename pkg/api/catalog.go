@@ -0,0 +1,278 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/pkg/errors"
+)
+
+// Catalog is a signed manifest of supported orchestrator versions, keyed the
+// same way as the compiled-in versionsMap.
+type Catalog struct {
+	Versions map[string][]string `json:"versions"`
+}
+
+// CatalogSource knows how to retrieve a Catalog from a particular origin.
+// Implementations do not need to verify the manifest signature themselves;
+// that is handled uniformly by RefreshCatalog.
+type CatalogSource interface {
+	// Fetch returns the raw manifest bytes and its detached signature.
+	Fetch(ctx context.Context) (manifest []byte, signature []byte, err error)
+}
+
+// NewFileCatalogSource returns a CatalogSource that reads a manifest and its
+// detached ".sig" sibling from local disk at manifestPath.
+func NewFileCatalogSource(manifestPath string) CatalogSource {
+	return &fileCatalogSource{ManifestPath: manifestPath}
+}
+
+// fileCatalogSource reads a manifest and its ".sig" sibling from local disk.
+type fileCatalogSource struct {
+	ManifestPath string
+}
+
+func (s *fileCatalogSource) Fetch(ctx context.Context) ([]byte, []byte, error) {
+	manifest, err := ioutil.ReadFile(s.ManifestPath)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading catalog manifest")
+	}
+	signature, err := ioutil.ReadFile(s.ManifestPath + ".sig")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading catalog signature")
+	}
+	return manifest, signature, nil
+}
+
+// NewHTTPSCatalogSource returns a CatalogSource that fetches a manifest and
+// its detached ".sig" sibling over HTTPS from manifestURL. A nil client
+// defaults to http.DefaultClient.
+func NewHTTPSCatalogSource(manifestURL string, client *http.Client) CatalogSource {
+	return &httpsCatalogSource{ManifestURL: manifestURL, Client: client}
+}
+
+// httpsCatalogSource fetches a manifest and its ".sig" sibling over HTTPS.
+type httpsCatalogSource struct {
+	ManifestURL string
+	Client      *http.Client
+}
+
+func (s *httpsCatalogSource) Fetch(ctx context.Context) ([]byte, []byte, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	manifest, err := s.get(ctx, client, s.ManifestURL)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fetching catalog manifest")
+	}
+	signature, err := s.get(ctx, client, s.ManifestURL+".sig")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fetching catalog signature")
+	}
+	return manifest, signature, nil
+}
+
+func (s *httpsCatalogSource) get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %d fetching %s", resp.StatusCode, url)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// embeddedCatalogSource returns the compiled-in versions as a Catalog. It
+// carries no signature; fetchVerifiedCatalog skips signature verification
+// for this source. It is the fallback used
+// whenever a remote source is unavailable or fails verification.
+type embeddedCatalogSource struct{}
+
+func (s *embeddedCatalogSource) Fetch(ctx context.Context) ([]byte, []byte, error) {
+	catalog := &Catalog{
+		Versions: map[string][]string{
+			Kubernetes: common.GetAllSupportedKubernetesVersions(true, false),
+			DCOS:       common.GetAllSupportedDCOSVersions(),
+			Swarm:      common.GetAllSupportedSwarmVersions(),
+			SwarmMode:  common.GetAllSupportedDockerCEVersions(),
+		},
+	}
+	manifest, err := json.Marshal(catalog)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The embedded source is trusted unconditionally; callers treat a nil
+	// signature paired with this source as already verified.
+	return manifest, nil, nil
+}
+
+// TrustedKeys holds the set of ed25519 public keys accepted when verifying a
+// catalog manifest's detached signature.
+var TrustedKeys []ed25519.PublicKey
+
+func verifyManifest(manifest, signature []byte, trustedKeys []ed25519.PublicKey) error {
+	if len(signature) == 0 {
+		return errors.New("catalog manifest is unsigned")
+	}
+	for _, key := range trustedKeys {
+		if ed25519.Verify(key, manifest, signature) {
+			return nil
+		}
+	}
+	return errors.New("catalog manifest signature does not match any trusted key")
+}
+
+const defaultCatalogTTL = 1 * time.Hour
+
+type catalogCache struct {
+	mu        sync.Mutex
+	catalog   *Catalog
+	fetchedAt time.Time
+	ttl       time.Duration
+	// source remembers the last non-embedded CatalogSource RefreshCatalog was
+	// asked to use, so a TTL-driven refresh from cachedCatalog retries the
+	// configured remote instead of quietly settling on the embedded catalog
+	// forever.
+	source CatalogSource
+}
+
+var globalCatalogCache = &catalogCache{ttl: defaultCatalogTTL}
+
+// RefreshCatalog fetches a fresh Catalog from source, verifies its signature
+// against TrustedKeys, and merges the result into the process-wide
+// versionsMap. If verification fails, or source is nil, the compiled-in
+// embedded catalog is used instead so callers always end up with a
+// deterministic, usable version list. When source's fetch or verification
+// failed and RefreshCatalog fell back to the embedded catalog, it still
+// returns that original error (wrapped) so the caller can tell the
+// configured source is broken, even though versionsMap was updated.
+func RefreshCatalog(ctx context.Context, source CatalogSource) error {
+	if source == nil {
+		source = &embeddedCatalogSource{}
+	}
+	catalog, sourceErr := fetchVerifiedCatalog(ctx, source)
+	if sourceErr != nil {
+		var err error
+		catalog, err = fetchVerifiedCatalog(ctx, &embeddedCatalogSource{})
+		if err != nil {
+			return err
+		}
+	}
+
+	versionsMapMu.Lock()
+	for orchestrator, versions := range catalog.Versions {
+		versionsMap[orchestrator] = versions
+	}
+	versionsMapMu.Unlock()
+
+	globalCatalogCache.mu.Lock()
+	globalCatalogCache.catalog = catalog
+	globalCatalogCache.fetchedAt = time.Now()
+	if _, isEmbedded := source.(*embeddedCatalogSource); !isEmbedded {
+		globalCatalogCache.source = source
+	}
+	globalCatalogCache.mu.Unlock()
+
+	if sourceErr != nil {
+		return errors.Wrap(sourceErr, "catalog source failed, fell back to the embedded catalog")
+	}
+	return nil
+}
+
+func fetchVerifiedCatalog(ctx context.Context, source CatalogSource) (*Catalog, error) {
+	if source == nil {
+		source = &embeddedCatalogSource{}
+	}
+	manifest, signature, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, isEmbedded := source.(*embeddedCatalogSource); !isEmbedded {
+		if err := verifyManifest(manifest, signature, TrustedKeys); err != nil {
+			return nil, err
+		}
+	}
+	catalog := &Catalog{}
+	if err := json.Unmarshal(manifest, catalog); err != nil {
+		return nil, errors.Wrap(err, "parsing catalog manifest")
+	}
+	return catalog, nil
+}
+
+// cachedCatalog returns the most recently refreshed Catalog. If the cache is
+// empty or has exceeded its TTL, it refreshes from whichever CatalogSource
+// was last configured via RefreshCatalog (falling back to the embedded
+// source while no such source has been configured, or if that source's
+// fetch/verify fails), so a previously configured remote manifest keeps getting retried
+// instead of being abandoned after one TTL window.
+func cachedCatalog(ctx context.Context) (*Catalog, error) {
+	globalCatalogCache.mu.Lock()
+	catalog := globalCatalogCache.catalog
+	stale := time.Since(globalCatalogCache.fetchedAt) > globalCatalogCache.ttl
+	source := globalCatalogCache.source
+	globalCatalogCache.mu.Unlock()
+
+	if catalog == nil || stale {
+		if source == nil {
+			source = &embeddedCatalogSource{}
+		}
+		// RefreshCatalog still populates the cache with the embedded catalog
+		// even when it returns an error for a failed configured source, so
+		// only treat this as fatal if the cache is still genuinely empty.
+		refreshErr := RefreshCatalog(ctx, source)
+		globalCatalogCache.mu.Lock()
+		catalog = globalCatalogCache.catalog
+		globalCatalogCache.mu.Unlock()
+		if catalog == nil {
+			return nil, refreshErr
+		}
+	}
+	return catalog, nil
+}
+
+// GetOrchestratorVersionProfileListWithCatalog behaves like
+// GetOrchestratorVersionProfileList, except the supported version lists come
+// from catalog instead of the compiled-in versionsMap. catalog.Versions is
+// threaded straight through to getOrchestratorVersionProfileList, so this
+// never touches the package-level versionsMap: concurrent calls (with
+// different catalogs, or against the global list) cannot race each other.
+// catalog only decides whether an explicitly given version is supported;
+// the versions listed when version is empty, and every upgrade target, still
+// come from the compiled-in lists in the common package.
+func GetOrchestratorVersionProfileListWithCatalog(catalog *Catalog, orchestrator, version string, windows bool) ([]*OrchestratorVersionProfile, error) {
+	if catalog == nil {
+		return nil, errors.New("catalog must not be nil")
+	}
+
+	return getOrchestratorVersionProfileList(catalog.Versions, orchestrator, version, windows)
+}
+
+// GetOrchestratorVersionProfileListFromCache is identical to
+// GetOrchestratorVersionProfileList, but draws its version lists from the
+// cached catalog (refreshed through cachedCatalog, from the last configured
+// source or else the embedded one, if the cache is empty or stale) rather
+// than the package-level versionsMap.
+func GetOrchestratorVersionProfileListFromCache(ctx context.Context, orchestrator, version string, windows bool) ([]*OrchestratorVersionProfile, error) {
+	catalog, err := cachedCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return GetOrchestratorVersionProfileListWithCatalog(catalog, orchestrator, version, windows)
+}
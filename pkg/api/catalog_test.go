@@ -0,0 +1,187 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fakeCatalogSource is a CatalogSource test double that returns canned
+// manifest/signature bytes (or an error), optionally recording how many
+// times it was fetched.
+type fakeCatalogSource struct {
+	manifest  []byte
+	signature []byte
+	err       error
+	fetches   int
+}
+
+func (s *fakeCatalogSource) Fetch(ctx context.Context) ([]byte, []byte, error) {
+	s.fetches++
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	return s.manifest, s.signature, nil
+}
+
+// resetCatalogState isolates each test from the package-level catalog cache,
+// trusted-key and versionsMap state, restoring all three on cleanup since
+// they're shared mutable globals.
+func resetCatalogState(t *testing.T) {
+	t.Helper()
+	previousCache := globalCatalogCache
+	previousKeys := TrustedKeys
+	previousVersions := versionsMap
+	globalCatalogCache = &catalogCache{ttl: defaultCatalogTTL}
+	versionsMap = map[string][]string{}
+	t.Cleanup(func() {
+		globalCatalogCache = previousCache
+		TrustedKeys = previousKeys
+		versionsMap = previousVersions
+	})
+}
+
+func marshalCatalog(t *testing.T, versions map[string][]string) []byte {
+	t.Helper()
+	manifest, err := json.Marshal(&Catalog{Versions: versions})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return manifest
+}
+
+func TestVerifyManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manifest := []byte(`{"versions":{"Kubernetes":["1.12.0"]}}`)
+	signature := ed25519.Sign(priv, manifest)
+
+	if err := verifyManifest(manifest, signature, []ed25519.PublicKey{pub}); err != nil {
+		t.Fatalf("expected a validly signed manifest to verify, got %v", err)
+	}
+
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := verifyManifest(manifest, signature, []ed25519.PublicKey{otherPub}); err == nil {
+		t.Fatalf("expected a signature from an untrusted key to be rejected")
+	}
+
+	if err := verifyManifest(manifest, nil, []ed25519.PublicKey{pub}); err == nil {
+		t.Fatalf("expected an unsigned manifest to be rejected")
+	}
+
+	tampered := append([]byte{}, manifest...)
+	tampered[0] = 'X'
+	if err := verifyManifest(tampered, signature, []ed25519.PublicKey{pub}); err == nil {
+		t.Fatalf("expected a tampered manifest to fail verification")
+	}
+}
+
+func TestRefreshCatalogUsesVerifiedSource(t *testing.T) {
+	resetCatalogState(t)
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	TrustedKeys = []ed25519.PublicKey{pub}
+
+	manifest := marshalCatalog(t, map[string][]string{Kubernetes: {"1.99.0"}})
+	source := &fakeCatalogSource{manifest: manifest, signature: ed25519.Sign(priv, manifest)}
+
+	if err := RefreshCatalog(context.Background(), source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	versionsMapMu.RLock()
+	got := versionsMap[Kubernetes]
+	versionsMapMu.RUnlock()
+	if len(got) != 1 || got[0] != "1.99.0" {
+		t.Fatalf("got versionsMap[Kubernetes] = %v, want [1.99.0]", got)
+	}
+}
+
+func TestRefreshCatalogFallsBackOnUntrustedSignature(t *testing.T) {
+	resetCatalogState(t)
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	TrustedKeys = []ed25519.PublicKey{pub}
+
+	_, untrustedPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manifest := marshalCatalog(t, map[string][]string{Kubernetes: {"1.99.0"}})
+	source := &fakeCatalogSource{manifest: manifest, signature: ed25519.Sign(untrustedPriv, manifest)}
+
+	err = RefreshCatalog(context.Background(), source)
+	if err == nil {
+		t.Fatalf("expected the untrusted signature to be surfaced as an error")
+	}
+
+	versionsMapMu.RLock()
+	got := versionsMap[Kubernetes]
+	versionsMapMu.RUnlock()
+	if len(got) == 1 && got[0] == "1.99.0" {
+		t.Fatalf("expected versionsMap to fall back to the embedded catalog, not the unverified manifest")
+	}
+}
+
+func TestRefreshCatalogSurfacesSourceErrorButStillPopulatesCache(t *testing.T) {
+	resetCatalogState(t)
+	source := &fakeCatalogSource{err: errors.New("network down")}
+
+	err := RefreshCatalog(context.Background(), source)
+	if err == nil {
+		t.Fatalf("expected the source's fetch error to be surfaced")
+	}
+
+	globalCatalogCache.mu.Lock()
+	catalog := globalCatalogCache.catalog
+	globalCatalogCache.mu.Unlock()
+	if catalog == nil {
+		t.Fatalf("expected RefreshCatalog to still populate the cache from the embedded fallback")
+	}
+}
+
+func TestCachedCatalogRetriesRememberedSourceWhenStale(t *testing.T) {
+	resetCatalogState(t)
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	TrustedKeys = []ed25519.PublicKey{pub}
+
+	manifest := marshalCatalog(t, map[string][]string{Kubernetes: {"1.50.0"}})
+	source := &fakeCatalogSource{manifest: manifest, signature: ed25519.Sign(priv, manifest)}
+
+	if err := RefreshCatalog(context.Background(), source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.fetches != 1 {
+		t.Fatalf("got %d fetches, want 1", source.fetches)
+	}
+
+	globalCatalogCache.mu.Lock()
+	globalCatalogCache.fetchedAt = time.Now().Add(-2 * globalCatalogCache.ttl)
+	globalCatalogCache.mu.Unlock()
+
+	if _, err := cachedCatalog(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.fetches != 2 {
+		t.Fatalf("got %d fetches after a stale cachedCatalog call, want 2 (expected the remembered source to be retried)", source.fetches)
+	}
+}
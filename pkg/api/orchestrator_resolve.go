@@ -0,0 +1,109 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	envOrchestrator       = "AKS_ENGINE_ORCHESTRATOR"
+	legacyEnvOrchestrator = "ACS_ENGINE_ORCHESTRATOR"
+)
+
+// DeprecationWarningWriter receives the deprecation notice emitted when
+// ResolveOrchestrator falls back to a legacy environment variable name. It
+// defaults to os.Stderr and may be swapped out (e.g. in tests) to capture or
+// silence the warning.
+var DeprecationWarningWriter io.Writer = os.Stderr
+
+// configFile is the subset of ~/.aks-engine/config.json that
+// ResolveOrchestrator understands.
+type configFile struct {
+	StackOrchestrator string `json:"stackOrchestrator"`
+}
+
+// ResolveOrchestrator determines which orchestrator a command should operate
+// on, so that operators scripting many `aks-engine` invocations don't have to
+// repeat --orchestrator on every call. Precedence, highest first:
+//
+//  1. flag, the explicit --orchestrator value, if set.
+//  2. the AKS_ENGINE_ORCHESTRATOR environment variable (or the deprecated
+//     ACS_ENGINE_ORCHESTRATOR, which emits a warning to DeprecationWarningWriter).
+//  3. the "stackOrchestrator" field of cfgFile (~/.aks-engine/config.json if
+//     cfgFile is empty).
+//  4. the compiled-in default, Kubernetes.
+//
+// The resolved value is passed through validate so callers get the same
+// normalization and error behavior as the rest of this package.
+func ResolveOrchestrator(flag, cfgFile, env string) (string, error) {
+	if flag != "" {
+		orch, err := validate(flag, "")
+		if err != nil {
+			return "", err
+		}
+		return orch, nil
+	}
+
+	if value := resolveOrchestratorEnv(env); value != "" {
+		orch, err := validate(value, "")
+		if err != nil {
+			return "", err
+		}
+		return orch, nil
+	}
+
+	if value := resolveOrchestratorConfigFile(cfgFile); value != "" {
+		orch, err := validate(value, "")
+		if err != nil {
+			return "", err
+		}
+		return orch, nil
+	}
+
+	return Kubernetes, nil
+}
+
+// resolveOrchestratorEnv returns env if set (used so tests can inject a
+// value without mutating process environment), otherwise it consults the
+// current and legacy environment variable names.
+func resolveOrchestratorEnv(env string) string {
+	if env != "" {
+		return env
+	}
+	if value := os.Getenv(envOrchestrator); value != "" {
+		return value
+	}
+	if value := os.Getenv(legacyEnvOrchestrator); value != "" {
+		fmt.Fprintf(DeprecationWarningWriter, "warning: %s is deprecated, use %s instead\n", legacyEnvOrchestrator, envOrchestrator)
+		return value
+	}
+	return ""
+}
+
+func resolveOrchestratorConfigFile(cfgFile string) string {
+	path := cfgFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, ".aks-engine", "config.json")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var cfg configFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return ""
+	}
+	return cfg.StackOrchestrator
+}
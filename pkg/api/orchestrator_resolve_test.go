@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveOrchestratorPrecedence(t *testing.T) {
+	cases := []struct {
+		name            string
+		flag            string
+		env             string
+		setRealEnv      string
+		setLegacyEnv    string
+		cfgOrchestrator string
+		want            string
+		wantWarning     bool
+	}{
+		{
+			name:            "explicit flag wins over everything else",
+			flag:            "dcos",
+			env:             "swarm",
+			cfgOrchestrator: "kubernetes",
+			want:            DCOS,
+		},
+		{
+			name:            "injected env value wins over config file",
+			env:             "kubernetes",
+			cfgOrchestrator: "swarm",
+			want:            Kubernetes,
+		},
+		{
+			name:            "real AKS_ENGINE_ORCHESTRATOR env var wins over config file",
+			setRealEnv:      "dockerce",
+			cfgOrchestrator: "kubernetes",
+			want:            SwarmMode,
+		},
+		{
+			name:         "legacy ACS_ENGINE_ORCHESTRATOR env var is honored with a deprecation warning",
+			setLegacyEnv: "swarm",
+			want:         Swarm,
+			wantWarning:  true,
+		},
+		{
+			name:            "config file stackOrchestrator used when no flag or env is set",
+			cfgOrchestrator: "dcos",
+			want:            DCOS,
+		},
+		{
+			name: "falls back to the compiled-in default",
+			want: Kubernetes,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.setRealEnv != "" {
+				os.Setenv(envOrchestrator, c.setRealEnv)
+				defer os.Unsetenv(envOrchestrator)
+			}
+			if c.setLegacyEnv != "" {
+				os.Setenv(legacyEnvOrchestrator, c.setLegacyEnv)
+				defer os.Unsetenv(legacyEnvOrchestrator)
+			}
+
+			cfgFile := ""
+			if c.cfgOrchestrator != "" {
+				dir, err := ioutil.TempDir("", "aks-engine-config")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				defer os.RemoveAll(dir)
+				cfgFile = filepath.Join(dir, "config.json")
+				contents := `{"stackOrchestrator":"` + c.cfgOrchestrator + `"}`
+				if err := ioutil.WriteFile(cfgFile, []byte(contents), 0600); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			var warnings bytes.Buffer
+			previousWriter := DeprecationWarningWriter
+			DeprecationWarningWriter = &warnings
+			defer func() { DeprecationWarningWriter = previousWriter }()
+
+			got, err := ResolveOrchestrator(c.flag, cfgFile, c.env)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != c.want {
+				t.Fatalf("got %q, want %q", got, c.want)
+			}
+			if c.wantWarning && warnings.Len() == 0 {
+				t.Fatalf("expected a deprecation warning, got none")
+			}
+			if !c.wantWarning && warnings.Len() != 0 {
+				t.Fatalf("got unexpected deprecation warning: %q", warnings.String())
+			}
+			if c.wantWarning && !strings.Contains(warnings.String(), legacyEnvOrchestrator) {
+				t.Fatalf("expected warning to mention %s, got %q", legacyEnvOrchestrator, warnings.String())
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package api
 import (
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Azure/aks-engine/pkg/api/common"
 	"github.com/Azure/aks-engine/pkg/api/vlabs"
@@ -13,10 +14,18 @@ import (
 	"github.com/pkg/errors"
 )
 
-type orchestratorsFunc func(*OrchestratorProfile, bool) ([]*OrchestratorVersionProfile, error)
+// orchestratorsFunc takes the versions map to consult (normally versionsMap,
+// or a Catalog's Versions when the caller supplied one) so that callers can
+// swap in an alternative version source without touching shared state.
+type orchestratorsFunc func(csOrch *OrchestratorProfile, hasWindows bool, versions map[string][]string) ([]*OrchestratorVersionProfile, error)
 
 var funcmap map[string]orchestratorsFunc
+
+// versionsMap is the process-wide, compiled-in version list, optionally
+// refreshed at runtime by RefreshCatalog. versionsMapMu guards every read and
+// write so that a refresh can never race with a lookup.
 var versionsMap map[string][]string
+var versionsMapMu sync.RWMutex
 
 func init() {
 	funcmap = map[string]orchestratorsFunc{
@@ -33,7 +42,22 @@ func init() {
 	}
 }
 
+// snapshotVersionsMap takes a point-in-time copy of versionsMap, so that a
+// single GetOrchestratorVersionProfileList/GetOrchestratorVersionProfile call
+// sees a consistent version set even if RefreshCatalog mutates versionsMap
+// concurrently.
+func snapshotVersionsMap() map[string][]string {
+	versionsMapMu.RLock()
+	defer versionsMapMu.RUnlock()
+	snapshot := make(map[string][]string, len(versionsMap))
+	for orchestrator, versions := range versionsMap {
+		snapshot[orchestrator] = versions
+	}
+	return snapshot
+}
+
 func validate(orchestrator, version string) (string, error) {
+	orchestrator = strings.TrimSpace(orchestrator)
 	switch {
 	case strings.EqualFold(orchestrator, Kubernetes):
 		return Kubernetes, nil
@@ -53,9 +77,9 @@ func validate(orchestrator, version string) (string, error) {
 	return "", nil
 }
 
-func isVersionSupported(csOrch *OrchestratorProfile) bool {
+func isVersionSupported(csOrch *OrchestratorProfile, versions map[string][]string) bool {
 	supported := false
-	for _, version := range versionsMap[csOrch.OrchestratorType] {
+	for _, version := range versions[csOrch.OrchestratorType] {
 
 		if version == csOrch.OrchestratorVersion {
 			supported = true
@@ -81,6 +105,14 @@ func GetOrchestratorVersionProfileListVLabs(orchestrator, version string, window
 
 // GetOrchestratorVersionProfileList returns a list of unversioned OrchestratorVersionProfile objects per (optionally) specified orchestrator and version
 func GetOrchestratorVersionProfileList(orchestrator, version string, windows bool) ([]*OrchestratorVersionProfile, error) {
+	return getOrchestratorVersionProfileList(snapshotVersionsMap(), orchestrator, version, windows)
+}
+
+// getOrchestratorVersionProfileList is the shared implementation behind
+// GetOrchestratorVersionProfileList and the Catalog-backed variants in
+// catalog.go; versions is threaded through explicitly so callers never need
+// to mutate the package-level versionsMap to inject an alternative source.
+func getOrchestratorVersionProfileList(versions map[string][]string, orchestrator, version string, windows bool) ([]*OrchestratorVersionProfile, error) {
 	var err error
 	if orchestrator, err = validate(orchestrator, version); err != nil {
 		return nil, err
@@ -90,14 +122,14 @@ func GetOrchestratorVersionProfileList(orchestrator, version string, windows boo
 		// return all orchestrators
 		for _, f := range funcmap {
 			var arr []*OrchestratorVersionProfile
-			arr, err = f(&OrchestratorProfile{}, false)
+			arr, err = f(&OrchestratorProfile{}, false, versions)
 			if err != nil {
 				return nil, err
 			}
 			orchs = append(orchs, arr...)
 		}
 	} else {
-		if orchs, err = funcmap[orchestrator](&OrchestratorProfile{OrchestratorType: orchestrator, OrchestratorVersion: version}, windows); err != nil {
+		if orchs, err = funcmap[orchestrator](&OrchestratorProfile{OrchestratorType: orchestrator, OrchestratorVersion: version}, windows, versions); err != nil {
 			return nil, err
 		}
 	}
@@ -109,9 +141,15 @@ func GetOrchestratorVersionProfile(orch *OrchestratorProfile, hasWindows bool) (
 	if orch.OrchestratorVersion == "" {
 		return nil, errors.New("Missing Orchestrator Version")
 	}
-	switch orch.OrchestratorType {
+	orchestratorType, err := resolveOrchestratorType(orch)
+	if err != nil {
+		return nil, err
+	}
+	switch orchestratorType {
 	case Kubernetes, DCOS:
-		arr, err := funcmap[orch.OrchestratorType](orch, hasWindows)
+		resolved := *orch
+		resolved.OrchestratorType = orchestratorType
+		arr, err := funcmap[orchestratorType](&resolved, hasWindows, snapshotVersionsMap())
 		if err != nil {
 			return nil, err
 		}
@@ -121,16 +159,30 @@ func GetOrchestratorVersionProfile(orch *OrchestratorProfile, hasWindows bool) (
 		}
 		return arr[0], nil
 	default:
-		return nil, errors.Errorf("Upgrade operation is not supported for '%s'", orch.OrchestratorType)
+		return nil, errors.Errorf("Upgrade operation is not supported for '%s'", orchestratorType)
+	}
+}
+
+// resolveOrchestratorType scopes orchestrator selection to this single
+// command: unlike validate, which requires an explicit orchestrator whenever
+// a version is given for the list-everything endpoint, a single-profile
+// lookup like GetOrchestratorVersionProfile has one natural orchestrator per
+// invocation, so an unset OrchestratorType falls back to the same
+// flag/env/config precedence ResolveOrchestrator uses for command-line
+// callers.
+func resolveOrchestratorType(orch *OrchestratorProfile) (string, error) {
+	if orch.OrchestratorType != "" {
+		return orch.OrchestratorType, nil
 	}
+	return ResolveOrchestrator("", "", "")
 }
 
-func kubernetesInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVersionProfile, error) {
+func kubernetesInfo(csOrch *OrchestratorProfile, hasWindows bool, versions map[string][]string) ([]*OrchestratorVersionProfile, error) {
 	orchs := []*OrchestratorVersionProfile{}
 	if csOrch.OrchestratorVersion == "" {
 		// get info for all supported versions
 		for _, ver := range common.GetAllSupportedKubernetesVersions(false, hasWindows) {
-			upgrades, err := kubernetesUpgrades(&OrchestratorProfile{OrchestratorVersion: ver}, hasWindows)
+			upgrades, err := kubernetesUpgrades(&OrchestratorProfile{OrchestratorVersion: ver, IncludeMultiHop: csOrch.IncludeMultiHop}, hasWindows)
 			if err != nil {
 				return nil, err
 			}
@@ -145,7 +197,7 @@ func kubernetesInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*Orchestrat
 				})
 		}
 	} else {
-		if !isVersionSupported(csOrch) {
+		if !isVersionSupported(csOrch, versions) {
 			return nil, errors.Errorf("Kubernetes version %s is not supported", csOrch.OrchestratorVersion)
 		}
 
@@ -168,17 +220,39 @@ func kubernetesInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*Orchestrat
 
 func kubernetesUpgrades(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorProfile, error) {
 	ret := []*OrchestratorProfile{}
+	seen := map[string]bool{}
 
-	upgradeVersions, err := getKubernetesAvailableUpgradeVersions(csOrch.OrchestratorVersion, common.GetAllSupportedKubernetesVersions(false, hasWindows))
+	supportedVersions := common.GetAllSupportedKubernetesVersions(false, hasWindows)
+	upgradeVersions, err := getKubernetesAvailableUpgradeVersions(csOrch.OrchestratorVersion, supportedVersions)
 	if err != nil {
 		return nil, err
 	}
 	for _, ver := range upgradeVersions {
+		seen[ver] = true
 		ret = append(ret, &OrchestratorProfile{
 			OrchestratorType:    Kubernetes,
 			OrchestratorVersion: ver,
 		})
 	}
+
+	// IncludeMultiHop additionally surfaces versions more than one minor
+	// away, reachable through an N-2-minor upgrade path, so existing
+	// single-hop callers are unaffected unless they opt in.
+	if csOrch.IncludeMultiHop {
+		for _, candidate := range supportedVersions {
+			if seen[candidate] || candidate == csOrch.OrchestratorVersion {
+				continue
+			}
+			if _, err := GetUpgradePath(csOrch.OrchestratorVersion, candidate, supportedVersions); err == nil {
+				seen[candidate] = true
+				ret = append(ret, &OrchestratorProfile{
+					OrchestratorType:    Kubernetes,
+					OrchestratorVersion: candidate,
+				})
+			}
+		}
+	}
+
 	return ret, nil
 }
 
@@ -207,7 +281,7 @@ func getKubernetesAvailableUpgradeVersions(orchestratorVersion string, supported
 
 }
 
-func dcosInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVersionProfile, error) {
+func dcosInfo(csOrch *OrchestratorProfile, hasWindows bool, versions map[string][]string) ([]*OrchestratorVersionProfile, error) {
 	orchs := []*OrchestratorVersionProfile{}
 	if csOrch.OrchestratorVersion == "" {
 		// get info for all supported versions
@@ -224,7 +298,7 @@ func dcosInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVers
 				})
 		}
 	} else {
-		if !isVersionSupported(csOrch) {
+		if !isVersionSupported(csOrch, versions) {
 			return nil, errors.Errorf("DCOS version %s is not supported", csOrch.OrchestratorVersion)
 		}
 
@@ -255,7 +329,7 @@ func dcosUpgrades(csOrch *OrchestratorProfile) []*OrchestratorProfile {
 	return ret
 }
 
-func swarmInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVersionProfile, error) {
+func swarmInfo(csOrch *OrchestratorProfile, hasWindows bool, versions map[string][]string) ([]*OrchestratorVersionProfile, error) {
 	if csOrch.OrchestratorVersion == "" {
 		return []*OrchestratorVersionProfile{
 			{
@@ -267,7 +341,7 @@ func swarmInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVer
 		}, nil
 	}
 
-	if !isVersionSupported(csOrch) {
+	if !isVersionSupported(csOrch, versions) {
 		return nil, errors.Errorf("Swarm version %s is not supported", csOrch.OrchestratorVersion)
 	}
 	return []*OrchestratorVersionProfile{
@@ -280,7 +354,7 @@ func swarmInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVer
 	}, nil
 }
 
-func dockerceInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVersionProfile, error) {
+func dockerceInfo(csOrch *OrchestratorProfile, hasWindows bool, versions map[string][]string) ([]*OrchestratorVersionProfile, error) {
 
 	if csOrch.OrchestratorVersion == "" {
 		return []*OrchestratorVersionProfile{
@@ -293,7 +367,7 @@ func dockerceInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*Orchestrator
 		}, nil
 	}
 
-	if !isVersionSupported(csOrch) {
+	if !isVersionSupported(csOrch, versions) {
 		return nil, errors.Errorf("Docker CE version %s is not supported", csOrch.OrchestratorVersion)
 	}
 	return []*OrchestratorVersionProfile{
@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"os"
+	"testing"
+)
+
+func TestValidateRequiresOrchestratorForVersion(t *testing.T) {
+	if _, err := validate("", "1.12.0"); err == nil {
+		t.Fatal("expected an error when a version is given without an orchestrator")
+	}
+}
+
+func TestResolveOrchestratorTypeUsesExplicitType(t *testing.T) {
+	got, err := resolveOrchestratorType(&OrchestratorProfile{OrchestratorType: DCOS})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != DCOS {
+		t.Fatalf("got %q, want %q", got, DCOS)
+	}
+}
+
+func TestResolveOrchestratorTypeFallsBackWhenUnset(t *testing.T) {
+	os.Setenv(envOrchestrator, "dcos")
+	defer os.Unsetenv(envOrchestrator)
+
+	got, err := resolveOrchestratorType(&OrchestratorProfile{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != DCOS {
+		t.Fatalf("got %q, want %q", got, DCOS)
+	}
+}
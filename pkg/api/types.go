@@ -0,0 +1,22 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+// OrchestratorProfile contains Orchestrator properties
+type OrchestratorProfile struct {
+	OrchestratorType    string
+	OrchestratorVersion string
+	// IncludeMultiHop opts a version lookup into N-2-minor upgrade planning:
+	// kubernetesUpgrades additionally surfaces versions reachable through
+	// GetUpgradePath, not just the next minor. Existing callers that leave
+	// this unset keep today's single-hop behavior.
+	IncludeMultiHop bool
+}
+
+// OrchestratorVersionProfile contains information about orchestrator upgrades available for a given version
+type OrchestratorVersionProfile struct {
+	OrchestratorProfile
+	Default  bool
+	Upgrades []*OrchestratorProfile
+}
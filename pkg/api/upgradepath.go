@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxUpgradeHops is the default limit on how many minor versions away
+// GetUpgradePath will plan a multi-hop upgrade before giving up and returning
+// ErrUpgradeTooFar.
+const DefaultMaxUpgradeHops = 3
+
+// ErrUpgradeTooFar is returned by GetUpgradePath when reaching To from From
+// would require more than MaxHops single-minor hops, and also when the walk
+// reaches a version with no newer supported version before reaching To.
+// PartialPath contains the versions that were planned before giving up, so
+// callers can still present progress to the user.
+type ErrUpgradeTooFar struct {
+	From        string
+	To          string
+	MaxHops     int
+	PartialPath []string
+}
+
+func (e *ErrUpgradeTooFar) Error() string {
+	return fmt.Sprintf("upgrade from %s to %s requires more than %d hops (reached %s)",
+		e.From, e.To, e.MaxHops, strings.Join(e.PartialPath, " -> "))
+}
+
+// GetUpgradePath returns the minimal ordered sequence of versions (including
+// from and to) required to move from from to to, never skipping more than
+// one minor version per hop, choosing the latest patch of each intermediate
+// minor from supported. If the path would take more than
+// DefaultMaxUpgradeHops hops, or to cannot be reached from supported, it
+// returns the partial path alongside an *ErrUpgradeTooFar.
+func GetUpgradePath(from, to string, supported []string) ([]string, error) {
+	return GetUpgradePathWithMaxHops(from, to, supported, DefaultMaxUpgradeHops)
+}
+
+// GetUpgradePathWithMaxHops is GetUpgradePath with a configurable hop limit.
+func GetUpgradePathWithMaxHops(from, to string, supported []string, maxHops int) ([]string, error) {
+	path := []string{from}
+	current := from
+
+	for hop := 0; hop < maxHops; hop++ {
+		if current == to {
+			return path, nil
+		}
+		next, err := getKubernetesAvailableUpgradeVersions(current, supported)
+		if err != nil {
+			return nil, err
+		}
+		if len(next) == 0 {
+			return path, &ErrUpgradeTooFar{From: from, To: to, MaxHops: maxHops, PartialPath: path}
+		}
+		if versionInList(to, next) {
+			path = append(path, to)
+			return path, nil
+		}
+		// Not reachable in this hop: take the latest patch of the next minor
+		// and keep walking toward to.
+		current = next[len(next)-1]
+		path = append(path, current)
+	}
+
+	if current == to {
+		return path, nil
+	}
+	return path, &ErrUpgradeTooFar{From: from, To: to, MaxHops: maxHops, PartialPath: path}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import "testing"
+
+func TestGetUpgradePath(t *testing.T) {
+	cases := []struct {
+		name      string
+		from      string
+		to        string
+		supported []string
+		maxHops   int
+		wantPath  []string
+		wantErr   bool
+	}{
+		{
+			name:      "patch-only upgrade within the same minor",
+			from:      "1.10.0",
+			to:        "1.10.8",
+			supported: []string{"1.10.0", "1.10.4", "1.10.8"},
+			maxHops:   3,
+			wantPath:  []string{"1.10.0", "1.10.8"},
+		},
+		{
+			name:      "single minor hop",
+			from:      "1.10.8",
+			to:        "1.11.2",
+			supported: []string{"1.10.8", "1.11.0", "1.11.2"},
+			maxHops:   3,
+			wantPath:  []string{"1.10.8", "1.11.2"},
+		},
+		{
+			name:      "two minor hops via synthesized intermediate",
+			from:      "1.9.10",
+			to:        "1.11.2",
+			supported: []string{"1.9.10", "1.10.0", "1.10.8", "1.11.0", "1.11.2"},
+			maxHops:   3,
+			wantPath:  []string{"1.9.10", "1.10.8", "1.11.2"},
+		},
+		{
+			name:      "missing intermediate minor still hops through the gap",
+			from:      "1.8.15",
+			to:        "1.11.2",
+			supported: []string{"1.8.15", "1.10.8", "1.11.2"},
+			maxHops:   3,
+			wantPath:  []string{"1.8.15", "1.10.8", "1.11.2"},
+		},
+		{
+			name:      "target more than maxHops minors away returns ErrUpgradeTooFar",
+			from:      "1.8.15",
+			to:        "1.14.0",
+			supported: []string{"1.8.15", "1.9.10", "1.10.8", "1.11.2", "1.12.0", "1.13.0", "1.14.0"},
+			maxHops:   2,
+			wantErr:   true,
+		},
+		{
+			name:      "pre-release alpha sentinel does not satisfy the target",
+			from:      "1.10.8",
+			to:        "1.12.0-alpha.0",
+			supported: []string{"1.10.8", "1.11.2", "1.12.0"},
+			maxHops:   3,
+			wantErr:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path, err := GetUpgradePathWithMaxHops(c.from, c.to, c.supported, c.maxHops)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got path %v", path)
+				}
+				tooFar, ok := err.(*ErrUpgradeTooFar)
+				if !ok {
+					t.Fatalf("expected *ErrUpgradeTooFar, got %T: %v", err, err)
+				}
+				if tooFar.From != c.from || tooFar.To != c.to {
+					t.Fatalf("unexpected ErrUpgradeTooFar fields: %+v", tooFar)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(path) != len(c.wantPath) {
+				t.Fatalf("got path %v, want %v", path, c.wantPath)
+			}
+			for i := range path {
+				if path[i] != c.wantPath[i] {
+					t.Fatalf("got path %v, want %v", path, c.wantPath)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/pkg/errors"
+)
+
+// componentVersions describes the component images/versions associated with a
+// given Kubernetes orchestrator version. Only the versions listed in
+// kubernetesComponentCatalog are covered; diffComponentVersions reports a hop
+// to or from any other version as unknown rather than as unchanged.
+type componentVersions struct {
+	HyperkubeImageBase       string
+	CloudControllerManager   string
+	KubeDNS                  string
+	CoreDNS                  string
+	Dashboard                string
+	AddonManager             string
+	DockerEngineVersionRange string
+}
+
+// kubernetesComponentCatalog is the per-version component table consulted by
+// GetUpgradePlan. It intentionally only covers the handful of components that
+// are visible to operators planning an upgrade; the full addon catalog lives
+// alongside the ARM template generation code.
+var kubernetesComponentCatalog = map[string]componentVersions{
+	"1.8.15": {HyperkubeImageBase: "hyperkube-amd64:v1.8.15", CloudControllerManager: "", KubeDNS: "1.14.13", CoreDNS: "", Dashboard: "1.8.3", AddonManager: "v6.4-beta.2", DockerEngineVersionRange: "1.11.2-1.13.1"},
+	"1.9.10": {HyperkubeImageBase: "hyperkube-amd64:v1.9.10", CloudControllerManager: "", KubeDNS: "1.14.13", CoreDNS: "", Dashboard: "1.8.3", AddonManager: "v6.4-beta.2", DockerEngineVersionRange: "1.11.2-1.13.1"},
+	"1.10.8": {HyperkubeImageBase: "hyperkube-amd64:v1.10.8", CloudControllerManager: "v1.10.4", KubeDNS: "1.14.13", CoreDNS: "1.1.3", Dashboard: "1.8.3", AddonManager: "v8.6", DockerEngineVersionRange: "1.11.2-17.03.2"},
+	"1.11.2": {HyperkubeImageBase: "hyperkube-amd64:v1.11.2", CloudControllerManager: "v1.11.1", KubeDNS: "1.14.13", CoreDNS: "1.1.3", Dashboard: "1.8.3", AddonManager: "v8.6", DockerEngineVersionRange: "1.11.2-17.03.2"},
+	"1.12.0": {HyperkubeImageBase: "hyperkube-amd64:v1.12.0", CloudControllerManager: "v1.12.0", KubeDNS: "1.14.13", CoreDNS: "1.2.2", Dashboard: "1.10.0", AddonManager: "v8.6", DockerEngineVersionRange: "17.03.2-18.06.1"},
+}
+
+// UpgradeHop describes the component-level impact of moving between two
+// adjacent supported versions during a multi-hop upgrade.
+type UpgradeHop struct {
+	FromVersion              string
+	ToVersion                string
+	ChangedComponents        map[string]string
+	DockerEngineRangeChanged bool
+	WindowsCompatible        bool
+}
+
+// UpgradePlan is the result of planning an upgrade from one Kubernetes
+// version to another. It reports the ordered set of hops required (no hop
+// skips more than one minor version) along with what changes at each hop.
+type UpgradePlan struct {
+	CurrentVersion string
+	TargetVersion  string
+	Hops           []*UpgradeHop
+	Warnings       []string
+}
+
+// GetUpgradePlan returns a structured, per-component breakdown of what will
+// change while upgrading current.OrchestratorVersion to target. Unlike
+// kubernetesUpgrades, which only enumerates reachable versions, GetUpgradePlan
+// walks every intermediate hop and surfaces the component deltas an operator
+// needs in order to judge whether the jump is safe.
+func GetUpgradePlan(current *OrchestratorProfile, target string, hasWindows bool) (*UpgradePlan, error) {
+	if current == nil || current.OrchestratorVersion == "" {
+		return nil, errors.New("Missing current Orchestrator Version")
+	}
+	if target == "" {
+		return nil, errors.New("Missing target Orchestrator Version")
+	}
+
+	// The path itself is computed against every supported version, not just
+	// the Windows-compatible subset: the plan needs to walk hops that aren't
+	// Windows-compatible in order to warn about them, rather than silently
+	// excluding them from the path.
+	supported := common.GetAllSupportedKubernetesVersions(false, false)
+	windowsSupported := common.GetAllSupportedKubernetesVersions(false, true)
+	return getUpgradePlan(current.OrchestratorVersion, target, hasWindows, supported, windowsSupported)
+}
+
+// getUpgradePlan is the version-list-injectable implementation behind
+// GetUpgradePlan, split out so tests can pin the Windows-compatibility
+// warning logic without depending on which Kubernetes versions are actually
+// compiled in.
+func getUpgradePlan(currentVersion, target string, hasWindows bool, supported, windowsSupported []string) (*UpgradePlan, error) {
+	path, err := getKubernetesUpgradePath(currentVersion, target, supported)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &UpgradePlan{
+		CurrentVersion: currentVersion,
+		TargetVersion:  target,
+	}
+
+	for i := 0; i < len(path)-1; i++ {
+		from := path[i]
+		to := path[i+1]
+		changed, knownComponents := diffComponentVersions(from, to)
+		hop := &UpgradeHop{
+			FromVersion:       from,
+			ToVersion:         to,
+			ChangedComponents: changed,
+			WindowsCompatible: versionInList(to, windowsSupported),
+		}
+		if hop.ChangedComponents["DockerEngineVersionRange"] != "" {
+			hop.DockerEngineRangeChanged = true
+		}
+		if !knownComponents {
+			plan.Warnings = append(plan.Warnings, "component version data for "+from+" -> "+to+" is not in kubernetesComponentCatalog; ChangedComponents may be incomplete")
+		}
+		if hasWindows && !hop.WindowsCompatible {
+			plan.Warnings = append(plan.Warnings, "version "+to+" does not support Windows node pools")
+		}
+		plan.Hops = append(plan.Hops, hop)
+	}
+
+	return plan, nil
+}
+
+// diffComponentVersions reports which fields of componentVersions differ
+// between from and to, along with whether both endpoints were present in
+// kubernetesComponentCatalog. When either endpoint is missing, the returned
+// diff is necessarily incomplete, and the caller must warn rather than imply
+// a verified no-op.
+func diffComponentVersions(from, to string) (map[string]string, bool) {
+	changed := map[string]string{}
+	fromComponents, fromOk := kubernetesComponentCatalog[from]
+	toComponents, toOk := kubernetesComponentCatalog[to]
+	if !fromOk || !toOk {
+		return changed, false
+	}
+	if fromComponents.HyperkubeImageBase != toComponents.HyperkubeImageBase {
+		changed["HyperkubeImageBase"] = toComponents.HyperkubeImageBase
+	}
+	if fromComponents.CloudControllerManager != toComponents.CloudControllerManager {
+		changed["CloudControllerManager"] = toComponents.CloudControllerManager
+	}
+	if fromComponents.KubeDNS != toComponents.KubeDNS {
+		changed["KubeDNS"] = toComponents.KubeDNS
+	}
+	if fromComponents.CoreDNS != toComponents.CoreDNS {
+		changed["CoreDNS"] = toComponents.CoreDNS
+	}
+	if fromComponents.Dashboard != toComponents.Dashboard {
+		changed["Dashboard"] = toComponents.Dashboard
+	}
+	if fromComponents.AddonManager != toComponents.AddonManager {
+		changed["AddonManager"] = toComponents.AddonManager
+	}
+	if fromComponents.DockerEngineVersionRange != toComponents.DockerEngineVersionRange {
+		changed["DockerEngineVersionRange"] = toComponents.DockerEngineVersionRange
+	}
+	return changed, true
+}
+
+func versionInList(version string, versions []string) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// getKubernetesUpgradePath returns the ordered list of versions (including
+// from and to) required to move from from to to, never skipping more than
+// one minor version per hop. GetUpgradePlan isn't bound by
+// DefaultMaxUpgradeHops since it needs to describe the full path even when
+// it's long, so it asks GetUpgradePathWithMaxHops for one more hop than there
+// are supported versions.
+func getKubernetesUpgradePath(from, to string, supported []string) ([]string, error) {
+	path, err := GetUpgradePathWithMaxHops(from, to, supported, len(supported)+1)
+	if err != nil {
+		if tooFar, ok := err.(*ErrUpgradeTooFar); ok {
+			return nil, errors.Errorf("no upgrade path from %s to %s", tooFar.From, tooFar.To)
+		}
+		return nil, err
+	}
+	return path, nil
+}
+
+// ConvertUpgradePlanToVLabs converts an UpgradePlan to the equivalent vlabs
+// representation so it can round-trip through the external API surface.
+func ConvertUpgradePlanToVLabs(plan *UpgradePlan) *vlabs.UpgradePlan {
+	if plan == nil {
+		return nil
+	}
+	vlabsPlan := &vlabs.UpgradePlan{
+		CurrentVersion: plan.CurrentVersion,
+		TargetVersion:  plan.TargetVersion,
+		Warnings:       plan.Warnings,
+	}
+	for _, hop := range plan.Hops {
+		vlabsPlan.Hops = append(vlabsPlan.Hops, &vlabs.UpgradeHop{
+			FromVersion:              hop.FromVersion,
+			ToVersion:                hop.ToVersion,
+			ChangedComponents:        hop.ChangedComponents,
+			DockerEngineRangeChanged: hop.DockerEngineRangeChanged,
+			WindowsCompatible:        hop.WindowsCompatible,
+		})
+	}
+	return vlabsPlan
+}
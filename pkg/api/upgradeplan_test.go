@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import "testing"
+
+func TestConvertUpgradePlanToVLabs(t *testing.T) {
+	plan := &UpgradePlan{
+		CurrentVersion: "1.10.8",
+		TargetVersion:  "1.12.0",
+		Warnings:       []string{"version 1.11.2 does not support Windows node pools"},
+		Hops: []*UpgradeHop{
+			{
+				FromVersion:              "1.10.8",
+				ToVersion:                "1.11.2",
+				ChangedComponents:        map[string]string{"CloudControllerManager": "v1.11.1"},
+				DockerEngineRangeChanged: false,
+				WindowsCompatible:        false,
+			},
+			{
+				FromVersion:              "1.11.2",
+				ToVersion:                "1.12.0",
+				ChangedComponents:        map[string]string{"DockerEngineVersionRange": "17.03.2-18.06.1"},
+				DockerEngineRangeChanged: true,
+				WindowsCompatible:        true,
+			},
+		},
+	}
+
+	vlabsPlan := ConvertUpgradePlanToVLabs(plan)
+
+	if vlabsPlan.CurrentVersion != plan.CurrentVersion || vlabsPlan.TargetVersion != plan.TargetVersion {
+		t.Fatalf("got %+v, want current/target to match %+v", vlabsPlan, plan)
+	}
+	if len(vlabsPlan.Warnings) != 1 || vlabsPlan.Warnings[0] != plan.Warnings[0] {
+		t.Fatalf("got warnings %v, want %v", vlabsPlan.Warnings, plan.Warnings)
+	}
+	if len(vlabsPlan.Hops) != len(plan.Hops) {
+		t.Fatalf("got %d hops, want %d", len(vlabsPlan.Hops), len(plan.Hops))
+	}
+	for i, hop := range plan.Hops {
+		vhop := vlabsPlan.Hops[i]
+		if vhop.FromVersion != hop.FromVersion || vhop.ToVersion != hop.ToVersion {
+			t.Fatalf("hop %d: got %+v, want versions to match %+v", i, vhop, hop)
+		}
+		if vhop.DockerEngineRangeChanged != hop.DockerEngineRangeChanged || vhop.WindowsCompatible != hop.WindowsCompatible {
+			t.Fatalf("hop %d: got %+v, want flags to match %+v", i, vhop, hop)
+		}
+		for component, version := range hop.ChangedComponents {
+			if vhop.ChangedComponents[component] != version {
+				t.Fatalf("hop %d: got changed component %s=%s, want %s", i, component, vhop.ChangedComponents[component], version)
+			}
+		}
+	}
+}
+
+func TestGetUpgradePlanWindowsIncompatibleHop(t *testing.T) {
+	supported := []string{"1.10.8", "1.11.2", "1.12.0"}
+	// 1.11.2 is deliberately left out of windowsSupported so the plan has to
+	// walk through a hop that isn't Windows-compatible.
+	windowsSupported := []string{"1.10.8", "1.12.0"}
+
+	plan, err := getUpgradePlan("1.10.8", "1.12.0", true, supported, windowsSupported)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Hops) != 2 {
+		t.Fatalf("got %d hops, want 2", len(plan.Hops))
+	}
+	if plan.Hops[0].WindowsCompatible {
+		t.Fatalf("expected the hop landing on 1.11.2 to be reported as Windows-incompatible")
+	}
+	if !plan.Hops[1].WindowsCompatible {
+		t.Fatalf("expected the hop landing on 1.12.0 to be reported as Windows-compatible")
+	}
+
+	found := false
+	for _, warning := range plan.Warnings {
+		if warning == "version 1.11.2 does not support Windows node pools" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a Windows-incompatibility warning, got %v", plan.Warnings)
+	}
+}
+
+func TestGetUpgradePlanIgnoresWindowsCompatibilityWhenNotRequested(t *testing.T) {
+	supported := []string{"1.10.8", "1.11.2", "1.12.0"}
+	windowsSupported := []string{"1.10.8", "1.12.0"}
+
+	plan, err := getUpgradePlan("1.10.8", "1.12.0", false, supported, windowsSupported)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Warnings) != 0 {
+		t.Fatalf("expected no Windows warnings when hasWindows is false, got %v", plan.Warnings)
+	}
+}
+
+func TestDiffComponentVersionsUnknownHop(t *testing.T) {
+	changed, known := diffComponentVersions("1.10.8", "9.9.9")
+	if known {
+		t.Fatalf("expected known=false for an uncataloged version")
+	}
+	if len(changed) != 0 {
+		t.Fatalf("expected an empty diff when catalog data is missing, got %v", changed)
+	}
+}
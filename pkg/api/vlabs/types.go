@@ -0,0 +1,13 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package vlabs
+
+// OrchestratorProfile is the vlabs representation of Orchestrator properties
+type OrchestratorProfile struct {
+	OrchestratorType    string `json:"orchestratorType"`
+	OrchestratorVersion string `json:"orchestratorVersion"`
+	// IncludeMultiHop mirrors api.OrchestratorProfile.IncludeMultiHop so the
+	// multi-hop opt-in round-trips through the external API surface.
+	IncludeMultiHop bool `json:"includeMultiHop,omitempty"`
+}
@@ -0,0 +1,21 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package vlabs
+
+// UpgradeHop is the vlabs representation of a single hop in an upgrade plan.
+type UpgradeHop struct {
+	FromVersion              string            `json:"fromVersion,omitempty"`
+	ToVersion                string            `json:"toVersion,omitempty"`
+	ChangedComponents        map[string]string `json:"changedComponents,omitempty"`
+	DockerEngineRangeChanged bool              `json:"dockerEngineRangeChanged,omitempty"`
+	WindowsCompatible        bool              `json:"windowsCompatible,omitempty"`
+}
+
+// UpgradePlan is the vlabs representation of an orchestrator upgrade plan.
+type UpgradePlan struct {
+	CurrentVersion string        `json:"currentVersion,omitempty"`
+	TargetVersion  string        `json:"targetVersion,omitempty"`
+	Hops           []*UpgradeHop `json:"hops,omitempty"`
+	Warnings       []string      `json:"warnings,omitempty"`
+}